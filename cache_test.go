@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// failNFetcher fails the first n calls, then succeeds on every call after.
+type failNFetcher struct {
+	n     int32
+	calls int32
+}
+
+func (fetcher *failNFetcher) Fetch(url string) (string, []string, error) {
+	if atomic.AddInt32(&fetcher.calls, 1) <= fetcher.n {
+		return "", nil, errors.New("transient failure")
+	}
+	return "ok", nil, nil
+}
+
+// TestResultCacheRetriesAfterError asserts that a failed fetch is not cached forever: a later
+// call for the same URL must retry rather than replaying the first error.
+func TestResultCacheRetriesAfterError(t *testing.T) {
+	inner := &failNFetcher{n: 1}
+	cache := newResultCache(newMemoryStore())
+
+	if _, _, err := cache.getOrAdd("http://x/", inner); err == nil {
+		t.Fatal("expected first fetch to fail")
+	}
+
+	body, _, err := cache.getOrAdd("http://x/", inner)
+	if err != nil {
+		t.Fatalf("expected second fetch to succeed, got err=%v", err)
+	}
+	if body != "ok" {
+		t.Fatalf("expected body %q, got %q", "ok", body)
+	}
+	if calls := atomic.LoadInt32(&inner.calls); calls != 2 {
+		t.Fatalf("expected inner fetcher called twice, got %d", calls)
+	}
+}
+
+// blockingFetcher blocks its first Fetch until released is closed, so tests can observe a
+// second, concurrent caller joining rather than triggering its own fetch.
+type blockingFetcher struct {
+	released chan struct{}
+	calls    int32
+}
+
+func (fetcher *blockingFetcher) Fetch(url string) (string, []string, error) {
+	atomic.AddInt32(&fetcher.calls, 1)
+	<-fetcher.released
+	return "ok", []string{"http://y/"}, nil
+}
+
+// TestResultCacheDedupsConcurrentCallers asserts that concurrent getOrAdd calls for the same
+// URL share a single fetch.
+func TestResultCacheDedupsConcurrentCallers(t *testing.T) {
+	inner := &blockingFetcher{released: make(chan struct{})}
+	cache := newResultCache(newMemoryStore())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.getOrAdd("http://x/", inner)
+		}()
+	}
+
+	close(inner.released)
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&inner.calls); calls != 1 {
+		t.Fatalf("expected inner fetcher called once, got %d", calls)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingFetcher is a Fetcher backed by a fixed page graph that records how many times each
+// URL was fetched, so tests can assert on dedup behavior.
+type recordingFetcher struct {
+	pages map[string]*fakeResult
+
+	mux   sync.Mutex
+	calls map[string]int
+}
+
+func newRecordingFetcher(pages map[string]*fakeResult) *recordingFetcher {
+	return &recordingFetcher{pages: pages, calls: make(map[string]int)}
+}
+
+func (fetcher *recordingFetcher) Fetch(url string) (string, []string, error) {
+	fetcher.mux.Lock()
+	fetcher.calls[url]++
+	fetcher.mux.Unlock()
+
+	page, ok := fetcher.pages[url]
+	if !ok {
+		return "", nil, fmt.Errorf("not found: %s", url)
+	}
+
+	return page.body, page.urls, nil
+}
+
+func (fetcher *recordingFetcher) callCount(url string) int {
+	fetcher.mux.Lock()
+	defer fetcher.mux.Unlock()
+
+	return fetcher.calls[url]
+}
+
+// TestCrawlClosesAfterAllGoroutines crawls a page graph containing a cycle, and asserts that
+// the channel is only closed once every spawned goroutine has finished, and that each URL is
+// only ever fetched once despite being reachable by more than one path.
+func TestCrawlClosesAfterAllGoroutines(t *testing.T) {
+	fetcher := newRecordingFetcher(map[string]*fakeResult{
+		"http://a/": {"a", []string{"http://b/", "http://c/"}},
+		"http://b/": {"b", []string{"http://a/", "http://c/"}},
+		"http://c/": {"c", []string{"http://a/"}},
+	})
+
+	channel := make(chan string)
+	go Crawl("http://a/", 3, fetcher, channel)
+
+	visited := map[string]bool{}
+	done := make(chan struct{})
+	go func() {
+		for url := range channel {
+			visited[url] = true
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for channel to close")
+	}
+
+	for _, url := range []string{"http://a/", "http://b/", "http://c/"} {
+		if !visited[url] {
+			t.Errorf("expected %s to be visited", url)
+		}
+		if calls := fetcher.callCount(url); calls != 1 {
+			t.Errorf("expected %s to be fetched exactly once, got %d", url, calls)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyTrackingFetcher records the maximum number of Fetch calls that were ever in
+// flight at once, so tests can assert a Crawler respects MaxWorkers.
+type concurrencyTrackingFetcher struct {
+	pages map[string]*fakeResult
+
+	current int32
+	peak    int32
+}
+
+func (fetcher *concurrencyTrackingFetcher) Fetch(url string) (string, []string, error) {
+	current := atomic.AddInt32(&fetcher.current, 1)
+	defer atomic.AddInt32(&fetcher.current, -1)
+
+	for {
+		peak := atomic.LoadInt32(&fetcher.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&fetcher.peak, peak, current) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	page := fetcher.pages[url]
+	if page == nil {
+		return url, nil, nil
+	}
+	return page.body, page.urls, nil
+}
+
+// TestCrawlerRunRespectsMaxWorkers crawls a page graph wide enough to have many pages ready
+// to fetch at once, and asserts the number of concurrent fetches never exceeds MaxWorkers.
+func TestCrawlerRunRespectsMaxWorkers(t *testing.T) {
+	root := &fakeResult{"root", nil}
+	pages := map[string]*fakeResult{"http://root/": root}
+	for i := 0; i < 20; i++ {
+		url := "http://root/" + string(rune('a'+i))
+		root.urls = append(root.urls, url)
+		pages[url] = &fakeResult{url, nil}
+	}
+
+	fetcher := &concurrencyTrackingFetcher{pages: pages}
+	crawler := &Crawler{MaxWorkers: 3, MaxDepth: 2, Fetcher: fetcher}
+
+	count := 0
+	for range crawler.Run("http://root/") {
+		count++
+	}
+
+	if count != len(pages) {
+		t.Fatalf("expected %d results, got %d", len(pages), count)
+	}
+	if peak := atomic.LoadInt32(&fetcher.peak); peak > 3 {
+		t.Fatalf("expected at most 3 concurrent fetches, saw %d", peak)
+	}
+}
@@ -2,18 +2,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"sync"
+	"time"
 )
 
-// main is the main program entry-point.
+// main is the main program entry-point. With no flags, it runs the bundled toy crawl against
+// fakeFetcher; passing -seed runs a real crawl over HTTP instead.
 func main() {
+	seed := flag.String("seed", "", "Seed URL to crawl for real, over HTTP. If empty, runs the bundled toy crawl instead.")
+	maxDepth := flag.Int("depth", 4, "Maximum crawl depth.")
+	maxWorkers := flag.Int("workers", 4, "Maximum number of concurrent fetches.")
+	cacheDir := flag.String("cache-dir", "", "Directory to persist fetch results in across runs. If empty, results are cached in memory only.")
+	flag.Parse()
+
+	if *seed != "" {
+		runLiveCrawl(*seed, *maxDepth, *maxWorkers, *cacheDir)
+		return
+	}
+
 	channel := make(chan string)
 
 	go Crawl("http://golang.org/", 4, fetcher, channel)
 
 	for {
-		visitedURL, ok := <- channel
+		visitedURL, ok := <-channel
 		if !ok {
 			break
 		}
@@ -22,24 +36,235 @@ func main() {
 	}
 }
 
+// runLiveCrawl crawls seed over real HTTP (honoring robots.txt and a polite per-host delay),
+// printing each page visited. If cacheDir is non-empty, fetch results are persisted there so a
+// later run over the same seed can skip pages it has already crawled.
+func runLiveCrawl(seed string, maxDepth int, maxWorkers int, cacheDir string) {
+	store, err := resultStoreFor(cacheDir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	crawler := &Crawler{
+		MaxWorkers: maxWorkers,
+		MaxDepth:   maxDepth,
+		Fetcher: newCachedFetcher(
+			newHTTPFetcher("golang-tutorial-crawler/1.0", 10*time.Second, 500*time.Millisecond),
+			store,
+		),
+	}
+
+	for result := range crawler.Run(seed) {
+		if result.Err != nil {
+			fmt.Printf("Error crawling %s: %v\n", result.URL, result.Err)
+			continue
+		}
+
+		fmt.Println("Visited URL: ", result.URL)
+	}
+}
+
 // Crawl uses fetcher to recursively crawl pages starting with url, to a maximum of depth.
-// Each page crawled will be sent to the channel; when all pages have been crawled, the channel will be closed.
+// Each page crawled will be sent to the channel; when all pages have been crawled (by this
+// call and all the goroutines it spawns), the channel will be closed.
 func Crawl(url string, depth int, fetcher Fetcher, channel chan string) {
-	if depth <= 0 {
+	visited := newVisitedURLs()
+
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(1)
+	go crawl(url, depth, fetcher, channel, visited, waitGroup)
+
+	go func() {
+		waitGroup.Wait()
 		close(channel)
+	}()
+}
+
+// crawl does the actual work of Crawl, decrementing waitGroup when this call (and all the
+// goroutines it spawns) are done.
+func crawl(url string, depth int, fetcher Fetcher, channel chan string, visited *visitedURLs, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
 
+	if depth <= 0 || !visited.markVisited(url) {
 		return
 	}
+
 	body, urls, err := fetcher.Fetch(url)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+
+	channel <- url
 	fmt.Printf("Found: %s %q\n", url, body)
+
+	for _, u := range urls {
+		waitGroup.Add(1)
+		go crawl(u, depth-1, fetcher, channel, visited, waitGroup)
+	}
+}
+
+////////////////
+// Visited URLs
+
+// visitedURLs is a mutex-guarded set of URLs that have already been crawled.
+type visitedURLs struct {
+	urls map[string]bool
+	mux  *sync.Mutex
+}
+
+// newVisitedURLs creates a new, empty visitedURLs set.
+func newVisitedURLs() *visitedURLs {
+	return &visitedURLs{
+		urls: make(map[string]bool),
+		mux:  &sync.Mutex{},
+	}
+}
+
+// markVisited records url as visited, returning true if it was not already visited.
+func (visited *visitedURLs) markVisited(url string) bool {
+	visited.mux.Lock()
+	defer visited.mux.Unlock()
+
+	if visited.urls[url] {
+		return false
+	}
+
+	visited.urls[url] = true
+	return true
+}
+
+/////////
+// Crawler
+
+// Result is the outcome of crawling a single URL.
+type Result struct {
+	// URL is the URL that was crawled.
+	URL string
+
+	// Body is the page body returned by the fetcher.
+	Body string
+
+	// URLs is the list of links discovered on the page.
+	URLs []string
+
+	// Err is any error that occurred while fetching URL.
+	Err error
+}
+
+// crawlItem is a unit of work popped off a Crawler's work queue.
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+// Crawler crawls pages starting from a seed URL, using a bounded pool of worker goroutines
+// so that the number of fetches in flight at any one time is capped at MaxWorkers.
+type Crawler struct {
+	// MaxWorkers is the maximum number of fetches that may be in flight at once.
+	MaxWorkers int
+
+	// MaxDepth is the maximum depth to crawl to, starting from the seed URL.
+	MaxDepth int
+
+	// Fetcher is used to fetch the body and discovered URLs for each page.
+	Fetcher Fetcher
+}
+
+// Run starts crawling from seed, returning a channel of Results that is closed once the
+// entire crawl (to MaxDepth, bounded by MaxWorkers concurrent fetches) has completed.
+func (crawler *Crawler) Run(seed string) <-chan Result {
+	results := make(chan Result)
+	queue := make(chan crawlItem)
+	pending := make(chan crawlItem)
+	visited := newVisitedURLs()
+	waitGroup := &sync.WaitGroup{}
+
+	workers := crawler.MaxWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go crawler.worker(queue, pending, results, visited, waitGroup)
+	}
+
+	go dispatch(pending, queue)
+
+	waitGroup.Add(1)
+	go func() {
+		pending <- crawlItem{seed, crawler.MaxDepth}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(pending)
+		close(results)
+	}()
+
+	return results
+}
+
+// dispatch relays items from pending to queue, buffering internally rather than blocking the
+// sender. This is what lets processItem hand off newly-discovered URLs with a plain channel
+// send instead of a goroutine per send: however many items are waiting for a free worker, only
+// this one goroutine is blocked on their behalf. dispatch closes queue once pending is closed
+// and its buffer has fully drained.
+func dispatch(pending <-chan crawlItem, queue chan<- crawlItem) {
+	var buffer []crawlItem
+	open := true
+
+	for open || len(buffer) > 0 {
+		var sendTo chan<- crawlItem
+		var next crawlItem
+		if len(buffer) > 0 {
+			sendTo = queue
+			next = buffer[0]
+		}
+
+		select {
+		case item, ok := <-pending:
+			if !ok {
+				pending = nil
+				open = false
+				continue
+			}
+			buffer = append(buffer, item)
+		case sendTo <- next:
+			buffer = buffer[1:]
+		}
+	}
+
+	close(queue)
+}
+
+// worker pops items off queue until it is closed, processing each in turn.
+func (crawler *Crawler) worker(queue <-chan crawlItem, pending chan<- crawlItem, results chan<- Result, visited *visitedURLs, waitGroup *sync.WaitGroup) {
+	for item := range queue {
+		crawler.processItem(item, pending, results, visited, waitGroup)
+	}
+}
+
+// processItem fetches a single queued item, publishes its Result, and enqueues any URLs it
+// discovers (subject to MaxDepth and visited) onto pending. It calls waitGroup.Done when item
+// itself (not its discoveries) is accounted for.
+func (crawler *Crawler) processItem(item crawlItem, pending chan<- crawlItem, results chan<- Result, visited *visitedURLs, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
+
+	if item.depth <= 0 || !visited.markVisited(item.url) {
+		return
+	}
+
+	body, urls, err := crawler.Fetcher.Fetch(item.url)
+	results <- Result{URL: item.url, Body: body, URLs: urls, Err: err}
+	if err != nil {
+		return
+	}
+
 	for _, u := range urls {
-		go Crawl(u, depth-1, fetcher, channel)
+		waitGroup.Add(1)
+		pending <- crawlItem{u, item.depth - 1}
 	}
-	return
 }
 
 //////////
@@ -82,6 +307,14 @@ type cachedFetcher struct {
 	cache        *resultCache
 }
 
+// newCachedFetcher creates a cachedFetcher that dedupes and persists inner's results in store.
+func newCachedFetcher(inner Fetcher, store ResultStore) *cachedFetcher {
+	return &cachedFetcher{
+		innerFetcher: inner,
+		cache:        newResultCache(store),
+	}
+}
+
 // Fetch retrieves the body and discovered URLs for the specified URL (using the cache if possible).
 func (fetcher *cachedFetcher) Fetch(url string) (body string, urls []string, err error) {
 	return fetcher.cache.getOrAdd(url, fetcher.innerFetcher)
@@ -90,68 +323,113 @@ func (fetcher *cachedFetcher) Fetch(url string) (body string, urls []string, err
 ////////
 // Cache
 
+// inflight represents a single fetch for a URL, shared by the first caller (who performs the
+// fetch) and any concurrent callers for the same URL (who wait on done instead of refetching).
+type inflight struct {
+	done   chan struct{}
+	result *Result
+	err    error
+}
+
+// resultCache dedupes concurrent fetches for the same URL: the first caller for a given URL
+// performs the fetch, while concurrent (and later) callers share its result. Fetches for
+// different URLs proceed fully in parallel. Completed results are persisted to store, so a
+// later getOrAdd (even in a subsequent process) can avoid the fetch entirely.
 type resultCache struct {
-	results map[string]*fakeResult
-	mux     *sync.Mutex
+	inFlight map[string]*inflight
+	mux      *sync.Mutex
+	store    ResultStore
 }
 
-// Retrieve a fetch result from the cache, or perform the fetch and add its result to the cache.
-func (cache *resultCache) getOrAdd(url string, fetcher Fetcher) (string, []string, error) {
-	cache.mux.Lock()
-	defer cache.mux.Unlock()
+// newResultCache creates a new, empty resultCache backed by store.
+func newResultCache(store ResultStore) *resultCache {
+	return &resultCache{
+		inFlight: make(map[string]*inflight),
+		mux:      &sync.Mutex{},
+		store:    store,
+	}
+}
 
+// Retrieve a fetch result from the cache: from store if already persisted, by joining an
+// in-flight fetch for url if one is already under way, or by performing the fetch (and
+// persisting its result) otherwise. Either way, the inFlight entry for url is removed once the
+// fetch resolves: successful fetches are already durable in store, and failed ones should not
+// replay the same error to later callers, who should retry instead.
+func (cache *resultCache) getOrAdd(url string, fetcher Fetcher) (string, []string, error) {
 	fmt.Printf("\tCache fetch: %v\n", url)
 
-	result, ok := cache.results[url]
+	if result, ok := cache.store.Get(url); ok {
+		fmt.Printf("\tCache hit (persisted): %v\n", url)
+		return result.Body, result.URLs, nil
+	}
+
+	cache.mux.Lock()
+	flight, ok := cache.inFlight[url]
 	if ok {
-		fmt.Printf("\tCache hit: %v\n", url)
-		return result.body, result.urls, nil
+		cache.mux.Unlock()
+
+		fmt.Printf("\tCache hit (joining in-flight fetch): %v\n", url)
+		<-flight.done
+
+		if flight.err != nil {
+			return "", nil, flight.err
+		}
+		return flight.result.Body, flight.result.URLs, nil
 	}
 
+	flight = &inflight{done: make(chan struct{})}
+	cache.inFlight[url] = flight
+	cache.mux.Unlock()
+
 	fmt.Printf("\tCache miss: %v\n", url)
 	body, urls, err := fetcher.Fetch(url)
+	flight.err = err
+
+	cache.mux.Lock()
 	if err == nil {
-		cache.results[url] = &fakeResult{body, urls}
+		flight.result = &Result{URL: url, Body: body, URLs: urls}
+		if storeErr := cache.store.Put(url, flight.result); storeErr != nil {
+			fmt.Println(storeErr)
+		}
 	}
+	delete(cache.inFlight, url)
+	cache.mux.Unlock()
+
+	close(flight.done)
 
 	return body, urls, err
 }
 
-// fetcher is a populated fakeFetcher.
-var fetcher = &cachedFetcher{
-	innerFetcher: fakeFetcher{
-		"http://golang.org/": &fakeResult{
-			"The Go Programming Language",
-			[]string{
-				"http://golang.org/pkg/",
-				"http://golang.org/cmd/",
-			},
-		},
-		"http://golang.org/pkg/": &fakeResult{
-			"Packages",
-			[]string{
-				"http://golang.org/",
-				"http://golang.org/cmd/",
-				"http://golang.org/pkg/fmt/",
-				"http://golang.org/pkg/os/",
-			},
+// fetcher is a cachedFetcher over a populated fakeFetcher, backed by an in-memory store.
+var fetcher = newCachedFetcher(fakeFetcher{
+	"http://golang.org/": &fakeResult{
+		"The Go Programming Language",
+		[]string{
+			"http://golang.org/pkg/",
+			"http://golang.org/cmd/",
 		},
-		"http://golang.org/pkg/fmt/": &fakeResult{
-			"Package fmt",
-			[]string{
-				"http://golang.org/",
-				"http://golang.org/pkg/",
-			},
+	},
+	"http://golang.org/pkg/": &fakeResult{
+		"Packages",
+		[]string{
+			"http://golang.org/",
+			"http://golang.org/cmd/",
+			"http://golang.org/pkg/fmt/",
+			"http://golang.org/pkg/os/",
 		},
-		"http://golang.org/pkg/os/": &fakeResult{
-			"Package os",
-			[]string{
-				"http://golang.org/",
-				"http://golang.org/pkg/",
-			},
+	},
+	"http://golang.org/pkg/fmt/": &fakeResult{
+		"Package fmt",
+		[]string{
+			"http://golang.org/",
+			"http://golang.org/pkg/",
 		},
 	},
-	cache: &resultCache{
-		make(map[string]*fakeResult), &sync.Mutex{},
+	"http://golang.org/pkg/os/": &fakeResult{
+		"Package os",
+		[]string{
+			"http://golang.org/",
+			"http://golang.org/pkg/",
+		},
 	},
-}
+}, newMemoryStore())
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/time/rate"
+)
+
+//////////////////
+// HTTP fetcher
+
+// httpFetcher is a Fetcher that performs real HTTP GETs, extracts links from the returned
+// HTML, and honors each host's robots.txt and a polite per-host request rate.
+type httpFetcher struct {
+	// UserAgent is sent as the User-Agent header, and used to evaluate robots.txt rules.
+	UserAgent string
+
+	// RequestDelay is the minimum delay between requests to the same host.
+	RequestDelay time.Duration
+
+	client  *http.Client
+	robots  *robotsCache
+	limiter *hostLimiter
+}
+
+// newHTTPFetcher creates a new httpFetcher with the given User-Agent, per-request timeout,
+// and minimum delay between requests to the same host.
+func newHTTPFetcher(userAgent string, timeout time.Duration, requestDelay time.Duration) *httpFetcher {
+	return &httpFetcher{
+		UserAgent:    userAgent,
+		RequestDelay: requestDelay,
+		client:       &http.Client{Timeout: timeout},
+		robots:       newRobotsCache(),
+		limiter:      newHostLimiter(requestDelay),
+	}
+}
+
+// Fetch retrieves url, honoring robots.txt and a polite per-host delay, and returns its body
+// along with the absolute URLs of every link found in it.
+func (fetcher *httpFetcher) Fetch(targetURL string) (body string, urls []string, err error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid URL %q: %w", targetURL, err)
+	}
+
+	allowed, err := fetcher.robots.isAllowed(fetcher.client, fetcher.UserAgent, parsedURL)
+	if err != nil {
+		return "", nil, err
+	}
+	if !allowed {
+		return "", nil, fmt.Errorf("disallowed by robots.txt: %s", targetURL)
+	}
+
+	fetcher.limiter.wait(parsedURL.Host)
+
+	request, err := http.NewRequest(http.MethodGet, targetURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	request.Header.Set("User-Agent", fetcher.UserAgent)
+
+	response, err := fetcher.client.Do(request)
+	if err != nil {
+		return "", nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("unexpected status fetching %s: %s", targetURL, response.Status)
+	}
+
+	bodyBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", nil, err
+	}
+	body = string(bodyBytes)
+
+	urls, err = extractLinks(parsedURL, strings.NewReader(body))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return body, urls, nil
+}
+
+// extractLinks parses htmlBody as HTML and returns the absolute form of every <a href> link
+// it contains, resolved against baseURL.
+func extractLinks(baseURL *url.URL, htmlBody io.Reader) ([]string, error) {
+	root, err := html.Parse(htmlBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "a" {
+			for _, attr := range node.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				if resolved, err := baseURL.Parse(attr.Val); err == nil {
+					urls = append(urls, resolved.String())
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return urls, nil
+}
+
+////////////
+// Robots.txt
+
+// robotsFetch is a single fetch of a host's robots.txt, shared by the first caller for that
+// host (who performs the fetch) and any concurrent callers for the same host (who wait on
+// done instead of fetching it again).
+type robotsFetch struct {
+	done  chan struct{}
+	rules *robotsRules
+	err   error
+}
+
+// robotsCache fetches and caches robots.txt rules, keyed by host, deduping concurrent callers
+// for the same host onto a single fetch.
+type robotsCache struct {
+	fetches map[string]*robotsFetch
+	mux     *sync.Mutex
+}
+
+// newRobotsCache creates a new, empty robotsCache.
+func newRobotsCache() *robotsCache {
+	return &robotsCache{
+		fetches: make(map[string]*robotsFetch),
+		mux:     &sync.Mutex{},
+	}
+}
+
+// isAllowed reports whether userAgent may fetch targetURL, fetching and caching the target
+// host's robots.txt the first time it is seen. Concurrent callers for the same host join the
+// one fetch rather than each fetching robots.txt themselves.
+func (cache *robotsCache) isAllowed(client *http.Client, userAgent string, targetURL *url.URL) (bool, error) {
+	cache.mux.Lock()
+	fetch, ok := cache.fetches[targetURL.Host]
+	if !ok {
+		fetch = &robotsFetch{done: make(chan struct{})}
+		cache.fetches[targetURL.Host] = fetch
+	}
+	cache.mux.Unlock()
+
+	if !ok {
+		fetch.rules, fetch.err = fetchRobotsRules(client, targetURL)
+		close(fetch.done)
+	} else {
+		<-fetch.done
+	}
+
+	if fetch.err != nil {
+		return false, fetch.err
+	}
+
+	return fetch.rules.allows(userAgent, targetURL.Path), nil
+}
+
+// robotsRules holds the Disallow prefixes declared for each user-agent in a robots.txt file.
+type robotsRules struct {
+	disallow map[string][]string
+}
+
+// fetchRobotsRules fetches and parses the robots.txt file for targetURL's host. A missing or
+// unparseable robots.txt is treated as "allow everything", per convention.
+func fetchRobotsRules(client *http.Client, targetURL *url.URL) (*robotsRules, error) {
+	robotsURL := &url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host, Path: "/robots.txt"}
+
+	response, err := client.Get(robotsURL.String())
+	if err != nil {
+		return &robotsRules{}, nil
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &robotsRules{}, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return &robotsRules{}, nil
+	}
+
+	return parseRobotsRules(string(body)), nil
+}
+
+// parseRobotsRules parses the body of a robots.txt file into a set of Disallow rules per
+// user-agent. Consecutive "User-agent:" lines are grouped together (so they share the rules
+// that follow); a "User-agent:" line that appears after a "Disallow:" has already been
+// recorded starts a new group. Directives other than "User-agent"/"Disallow" (such as "Allow",
+// "Crawl-delay" or "Sitemap") are ignored rather than ending the current group.
+func parseRobotsRules(body string) *robotsRules {
+	rules := &robotsRules{disallow: make(map[string][]string)}
+
+	var currentAgents []string
+	sawDisallow := false
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			currentAgents = nil
+			sawDisallow = false
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			if sawDisallow {
+				currentAgents = nil
+				sawDisallow = false
+			}
+			currentAgents = append(currentAgents, strings.ToLower(value))
+		case "disallow":
+			sawDisallow = true
+			if value == "" {
+				continue
+			}
+			for _, agent := range currentAgents {
+				rules.disallow[agent] = append(rules.disallow[agent], value)
+			}
+		}
+	}
+
+	return rules
+}
+
+// allows reports whether userAgent is permitted to fetch path, checking both its specific
+// rules and the wildcard ("*") rules.
+func (rules *robotsRules) allows(userAgent string, path string) bool {
+	for _, agent := range []string{strings.ToLower(userAgent), "*"} {
+		for _, prefix := range rules.disallow[agent] {
+			if strings.HasPrefix(path, prefix) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+/////////////////////
+// Per-host rate limit
+
+// hostLimiter enforces a minimum delay between requests to the same host.
+type hostLimiter struct {
+	delay    time.Duration
+	limiters map[string]*rate.Limiter
+	mux      *sync.Mutex
+}
+
+// newHostLimiter creates a hostLimiter that allows at most one request per delay, per host.
+func newHostLimiter(delay time.Duration) *hostLimiter {
+	return &hostLimiter{
+		delay:    delay,
+		limiters: make(map[string]*rate.Limiter),
+		mux:      &sync.Mutex{},
+	}
+}
+
+// wait blocks until a request to host is allowed under this limiter's per-host rate.
+func (hostLimiter *hostLimiter) wait(host string) {
+	hostLimiter.mux.Lock()
+	limiter, ok := hostLimiter.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(hostLimiter.delay), 1)
+		hostLimiter.limiters[host] = limiter
+	}
+	hostLimiter.mux.Unlock()
+
+	limiter.Wait(context.Background())
+}
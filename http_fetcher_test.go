@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobotsRulesMultiDirectiveGroup(t *testing.T) {
+	rules := parseRobotsRules("User-agent: *\nAllow: /public/\nDisallow: /private/\nCrawl-delay: 10\nSitemap: /sitemap.xml\n")
+
+	if rules.allows("anybot", "/private/foo") {
+		t.Error("expected /private/foo to be disallowed for *")
+	}
+	if !rules.allows("anybot", "/public/foo") {
+		t.Error("expected /public/foo to be allowed")
+	}
+}
+
+func TestParseRobotsRulesNewGroupAfterDisallow(t *testing.T) {
+	rules := parseRobotsRules("User-agent: a\nDisallow: /a-only/\nUser-agent: b\nDisallow: /b-only/\n")
+
+	if rules.allows("a", "/a-only/x") {
+		t.Error("expected /a-only/ to be disallowed for a")
+	}
+	if !rules.allows("a", "/b-only/x") {
+		t.Error("expected /b-only/ to remain allowed for a")
+	}
+	if rules.allows("b", "/b-only/x") {
+		t.Error("expected /b-only/ to be disallowed for b")
+	}
+}
+
+func TestRobotsRulesAllowsWildcardVsSpecific(t *testing.T) {
+	rules := parseRobotsRules("User-agent: *\nDisallow: /all/\nUser-agent: specialbot\nDisallow: /special/\n")
+
+	if rules.allows("anybot", "/all/x") {
+		t.Error("expected /all/ to be disallowed for every agent, via the wildcard group")
+	}
+	if rules.allows("specialbot", "/all/x") {
+		t.Error("expected /all/ to still be disallowed for specialbot, via the wildcard group")
+	}
+	if !rules.allows("anybot", "/special/x") {
+		t.Error("expected /special/ to only apply to specialbot, not other agents")
+	}
+	if rules.allows("specialbot", "/special/x") {
+		t.Error("expected /special/ to be disallowed for specialbot")
+	}
+}
+
+func TestExtractLinksResolvesRelativeHrefs(t *testing.T) {
+	base, err := url.Parse("http://example.com/dir/page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := `<html><body>
+		<a href="relative.html">Relative</a>
+		<a href="/absolute-path">Absolute path</a>
+		<a href="http://other.com/x">Other host</a>
+		<a href="../up.html">Up a level</a>
+	</body></html>`
+
+	urls, err := extractLinks(base, strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{
+		"http://example.com/dir/relative.html": true,
+		"http://example.com/absolute-path":     true,
+		"http://other.com/x":                   true,
+		"http://example.com/up.html":           true,
+	}
+
+	if len(urls) != len(want) {
+		t.Fatalf("expected %d urls, got %v", len(want), urls)
+	}
+	for _, u := range urls {
+		if !want[u] {
+			t.Errorf("unexpected url: %s", u)
+		}
+	}
+}
+
+func TestHTTPFetcherFetchExtractsLinksAndHonorsRobots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/public/page.html">Public</a></body></html>`)
+		case "/private/secret.html":
+			t.Error("fetcher should not have requested a disallowed path")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := newHTTPFetcher("test-agent", 5*time.Second, time.Millisecond)
+
+	body, urls, err := fetcher.Fetch(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Fetch(%q) returned error: %v", server.URL+"/", err)
+	}
+	if !strings.Contains(body, "Public") {
+		t.Errorf("expected body to contain the page content, got %q", body)
+	}
+
+	want := server.URL + "/public/page.html"
+	if len(urls) != 1 || urls[0] != want {
+		t.Errorf("expected urls to be [%q], got %v", want, urls)
+	}
+
+	if _, _, err := fetcher.Fetch(server.URL + "/private/secret.html"); err == nil {
+		t.Error("expected Fetch of a robots.txt-disallowed path to return an error")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+/////////////////
+// Result store
+
+// ResultStore persists fetch results across process restarts, so that a crawler resuming a
+// prior run does not need to re-fetch pages it has already crawled.
+type ResultStore interface {
+	// Get returns the stored result for url, if any.
+	Get(url string) (*Result, bool)
+
+	// Put stores result for url.
+	Put(url string, result *Result) error
+}
+
+// resultStoreFor returns the ResultStore to use for a live crawl: a fileStore rooted at
+// cacheDir if one is given, or a memoryStore otherwise.
+func resultStoreFor(cacheDir string) (ResultStore, error) {
+	if cacheDir == "" {
+		return newMemoryStore(), nil
+	}
+
+	return newFileStore(cacheDir)
+}
+
+////////////////
+// Memory store
+
+// memoryStore is a ResultStore backed by an in-memory map. It does not persist across
+// restarts; it exists so that resultCache always has a ResultStore to talk to even when no
+// persistent backend is configured.
+type memoryStore struct {
+	results map[string]*Result
+	mux     *sync.Mutex
+}
+
+// newMemoryStore creates a new, empty memoryStore.
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		results: make(map[string]*Result),
+		mux:     &sync.Mutex{},
+	}
+}
+
+func (store *memoryStore) Get(url string) (*Result, bool) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	result, ok := store.results[url]
+	return result, ok
+}
+
+func (store *memoryStore) Put(url string, result *Result) error {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	store.results[url] = result
+	return nil
+}
+
+////////////////////
+// Filesystem store
+
+// fileStore is a ResultStore that persists each result as a gzip-compressed, gob-encoded
+// file in Dir, named after the SHA-256 hash of the URL.
+type fileStore struct {
+	Dir string
+}
+
+// newFileStore creates a fileStore rooted at dir, creating dir if it does not already exist.
+func newFileStore(dir string) (*fileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create file store directory %q: %w", dir, err)
+	}
+
+	return &fileStore{Dir: dir}, nil
+}
+
+// pathFor returns the file path that url's result is (or would be) stored at.
+func (store *fileStore) pathFor(url string) string {
+	hash := sha256.Sum256([]byte(url))
+
+	return filepath.Join(store.Dir, hex.EncodeToString(hash[:])+".gob.gz")
+}
+
+func (store *fileStore) Get(url string) (*Result, bool) {
+	file, err := os.Open(store.pathFor(url))
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, false
+	}
+	defer gzipReader.Close()
+
+	var result Result
+	if err := gob.NewDecoder(gzipReader).Decode(&result); err != nil {
+		return nil, false
+	}
+
+	return &result, true
+}
+
+func (store *fileStore) Put(url string, result *Result) error {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	if err := gob.NewEncoder(gzipWriter).Encode(result); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(store.pathFor(url), buffer.Bytes(), 0o644)
+}
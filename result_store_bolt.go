@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+/////////////
+// Bolt store
+
+// resultsBucket is the single BoltDB bucket that boltStore keeps results in.
+var resultsBucket = []byte("results")
+
+// boltStore is a ResultStore backed by a BoltDB database file, keyed by URL.
+type boltStore struct {
+	db *bolt.DB
+}
+
+// newBoltStore opens (creating if necessary) the BoltDB database at path, and ensures its
+// results bucket exists.
+func newBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0o644, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open Bolt database %q: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(resultsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot create results bucket in %q: %w", path, err)
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+// Close releases the underlying BoltDB database file.
+func (store *boltStore) Close() error {
+	return store.db.Close()
+}
+
+func (store *boltStore) Get(url string) (*Result, bool) {
+	var result Result
+	found := false
+
+	store.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(resultsBucket).Get([]byte(url))
+		if value == nil {
+			return nil
+		}
+
+		if err := gob.NewDecoder(bytes.NewReader(value)).Decode(&result); err != nil {
+			return nil
+		}
+
+		found = true
+		return nil
+	})
+
+	if !found {
+		return nil, false
+	}
+	return &result, true
+}
+
+func (store *boltStore) Put(url string, result *Result) error {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(result); err != nil {
+		return err
+	}
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(resultsBucket).Put([]byte(url), buffer.Bytes())
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//////////////
+// Redis store
+
+// redisStore is a ResultStore backed by a Redis server, keyed by URL under KeyPrefix.
+type redisStore struct {
+	client    *redis.Client
+	KeyPrefix string
+}
+
+// newRedisStore creates a redisStore talking to the Redis server at addr, storing keys under
+// keyPrefix (so that the same server can host stores for multiple crawlers).
+func newRedisStore(addr string, keyPrefix string) *redisStore {
+	return &redisStore{
+		client:    redis.NewClient(&redis.Options{Addr: addr}),
+		KeyPrefix: keyPrefix,
+	}
+}
+
+// Close releases the underlying Redis client's connections.
+func (store *redisStore) Close() error {
+	return store.client.Close()
+}
+
+func (store *redisStore) key(url string) string {
+	return store.KeyPrefix + url
+}
+
+func (store *redisStore) Get(url string) (*Result, bool) {
+	value, err := store.client.Get(context.Background(), store.key(url)).Bytes()
+	if errors.Is(err, redis.Nil) || err != nil {
+		return nil, false
+	}
+
+	var result Result
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(&result); err != nil {
+		return nil, false
+	}
+
+	return &result, true
+}
+
+func (store *redisStore) Put(url string, result *Result) error {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(result); err != nil {
+		return err
+	}
+
+	if err := store.client.Set(context.Background(), store.key(url), buffer.Bytes(), 0).Err(); err != nil {
+		return fmt.Errorf("cannot store result for %q in Redis: %w", url, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TestFileStorePersistsAcrossInstances asserts that a result Put by one fileStore can be read
+// back by a fresh fileStore pointed at the same directory, i.e. that it survives a restart.
+func TestFileStorePersistsAcrossInstances(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := newFileStore(dir)
+	if err != nil {
+		t.Fatalf("newFileStore: %v", err)
+	}
+
+	want := &Result{URL: "http://x/", Body: "hello", URLs: []string{"http://x/a", "http://x/b"}}
+	if err := store.Put(want.URL, want); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	restarted, err := newFileStore(dir)
+	if err != nil {
+		t.Fatalf("newFileStore (restarted): %v", err)
+	}
+
+	got, ok := restarted.Get(want.URL)
+	if !ok {
+		t.Fatal("expected a fresh fileStore over the same directory to find the persisted result")
+	}
+	if got.Body != want.Body || len(got.URLs) != len(want.URLs) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestBoltStorePersistsAcrossInstances asserts that a result Put by one boltStore can be read
+// back by a fresh boltStore pointed at the same database file, i.e. that it survives a restart.
+func TestBoltStorePersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.bolt")
+
+	store, err := newBoltStore(path)
+	if err != nil {
+		t.Fatalf("newBoltStore: %v", err)
+	}
+
+	want := &Result{URL: "http://x/", Body: "hello", URLs: []string{"http://x/a"}}
+	if err := store.Put(want.URL, want); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	restarted, err := newBoltStore(path)
+	if err != nil {
+		t.Fatalf("newBoltStore (restarted): %v", err)
+	}
+	defer restarted.Close()
+
+	got, ok := restarted.Get(want.URL)
+	if !ok {
+		t.Fatal("expected a fresh boltStore over the same file to find the persisted result")
+	}
+	if got.Body != want.Body || len(got.URLs) != len(want.URLs) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestRedisStorePersistsAcrossInstances asserts that a result Put by one redisStore can be read
+// back by a fresh redisStore talking to the same server, i.e. that it survives a restart. It is
+// skipped if no Redis server is reachable at localhost:6379.
+func TestRedisStorePersistsAcrossInstances(t *testing.T) {
+	probe := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer probe.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := probe.Ping(ctx).Err(); err != nil {
+		t.Skipf("no Redis server reachable at localhost:6379: %v", err)
+	}
+
+	keyPrefix := "golang-tutorial-test:"
+	store := newRedisStore("localhost:6379", keyPrefix)
+	defer store.Close()
+
+	want := &Result{URL: "http://x/", Body: "hello", URLs: []string{"http://x/a"}}
+	if err := store.Put(want.URL, want); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	restarted := newRedisStore("localhost:6379", keyPrefix)
+	defer restarted.Close()
+
+	got, ok := restarted.Get(want.URL)
+	if !ok {
+		t.Fatal("expected a fresh redisStore over the same server to find the persisted result")
+	}
+	if got.Body != want.Body || len(got.URLs) != len(want.URLs) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}